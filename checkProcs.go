@@ -1,12 +1,17 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
+	"os/user"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/wolfedale/checkProcs/pkg/procs"
 )
 
 // Exit codes for monitoring Sensu/Nagios
@@ -24,225 +29,401 @@ const (
 	UNKNOWN = 3
 )
 
-// Process is the generic interface that is implemented on every platform
-// and provides common operations for processes.
-type Process interface {
-	// Pid is the process ID for this process.
-	Pid() int
+// unset marks an int flag (--ppid, --min, --max, --warn-min, --warn-max)
+// as not having been passed, since 0 is itself a meaningful value for
+// several of them.
+const unset = -1
 
-	// PPid is the parent process ID for this process.
-	PPid() int
+// options holds every matcher and threshold flag accepted by the CLI.
+type options struct {
+	exact         string
+	regex         *regexp.Regexp
+	cmdlineSubstr string
+	ppid          int
+	user          string
 
-	// Executable name running this process. This is not a path to the
-	// executable.
-	Executable() string
-}
+	min, max         int
+	warnMin, warnMax int
+
+	tree   bool
+	output string
 
-// Processes returns all processes.
-//
-// This of course will be a point-in-time snapshot of when this method was
-// called. Some operating systems don't provide snapshot capability of the
-// process table, in which case the process table returned might contain
-// ephemeral entities that happened to be running when this was called.
-//
-// Example:
-// procs, _ := Processes()
-//
-func Processes() ([]Process, error) {
-	return processes()
+	cpuSampleInterval time.Duration
 }
 
-// FindProcess looks up a single process by pid.
-//
-// Process will be nil and error will be nil if a matching process is
-// not found.
-//
-// Example:
-// foo, _ := FindProcess(4256)
-// fmt.Println(foo.Executable(), foo.Pid())
-// for _, i := range procs {
-//   if i.Executable()
-func FindProcess(pid int) (Process, error) {
-	return findProcess(pid)
+// Main function to actually start programm
+func main() {
+	opts := Command()
+
+	matches, err := findMatches(opts)
+	if err != nil {
+		fmt.Printf("Error: %v", err)
+		os.Exit(UNKNOWN)
+	}
+
+	if opts.cpuSampleInterval > 0 {
+		matches = resampleCPU(matches, opts.cpuSampleInterval)
+	}
+
+	code, label := evaluate(len(matches), opts)
+
+	if opts.output == "json" {
+		printJSON(matches, opts, code, label)
+		os.Exit(code)
+	}
+
+	fmt.Printf("%s: %d process(es) matching %s", label, len(matches), describeMatch(opts))
+	for _, p := range matches {
+		fmt.Printf("\n  pid: %d, ppid: %d, exe: %v", p.Pid(), p.PPid(), p.Executable())
+		if opts.tree {
+			printTree(p)
+		}
+	}
+	fmt.Printf("\n%s\n", perfdata(matches, opts))
+
+	os.Exit(code)
 }
 
-// UnixProcess is an implementation of Process that contains Unix-specific
-// fields and information.
-type UnixProcess struct {
-	pid   int
-	ppid  int
-	state rune
-	pgrp  int
-	sid   int
+// findMatches lists every process on the system and returns the ones
+// satisfying opts's matchers.
+func findMatches(opts options) ([]procs.Process, error) {
+	run, err := procs.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []procs.Process
+	for _, p := range run {
+		if matchesOptions(p, opts) {
+			matches = append(matches, p)
+		}
+	}
 
-	binary string
+	return matches, nil
 }
 
-// Pid simply return pid of the specific process
-func (p *UnixProcess) Pid() int {
-	return p.pid
+// cpuSecondsReader is satisfied by a procs.Process that can report its
+// cumulative CPU time, letting resampleCPU diff two readings instead of
+// relying on PCpu's whole-lifetime average. Not every platform's Process
+// implements it (see pkg/procs); matches that don't are left untouched.
+type cpuSecondsReader interface {
+	CPUSeconds() (float64, error)
 }
 
-// PPid simply return ppid of the specific process
-func (p *UnixProcess) PPid() int {
-	return p.ppid
+// sampledProcess wraps a procs.Process, overriding PCpu with a windowed
+// rate computed by resampleCPU.
+type sampledProcess struct {
+	procs.Process
+	pcpu float64
 }
 
-// Executable simply return name of the process
-func (p *UnixProcess) Executable() string {
-	return p.binary
+// PCpu returns the windowed rate resampleCPU computed for this process,
+// rather than the embedded Process's own lifetime average.
+func (s sampledProcess) PCpu() (float64, error) {
+	return s.pcpu, nil
 }
 
-// findProcess is returning all information about the specific process
-func findProcess(pid int) (Process, error) {
-	dir := fmt.Sprintf("/proc/%d", pid)
-	_, err := os.Stat(dir)
-	if err != nil {
-		// file does not exist
-		if os.IsNotExist(err) {
-			return nil, nil
+// resampleCPU replaces each match's PCpu() with a rate computed by
+// reading its cumulative CPU time, sleeping interval, reading it again,
+// and dividing the difference by the elapsed wall-clock time. This is
+// the %CPU a long-lived daemon is actually spending right now, unlike
+// PCpu's own average over its entire (possibly days-long) lifetime,
+// which a single extra sample barely moves. Matches whose platform has
+// no way to expose cumulative CPU time keep their original PCpu().
+func resampleCPU(matches []procs.Process, interval time.Duration) []procs.Process {
+	before := make([]float64, len(matches))
+	hasBefore := make([]bool, len(matches))
+	for i, p := range matches {
+		if r, ok := p.(cpuSecondsReader); ok {
+			if s, err := r.CPUSeconds(); err == nil {
+				before[i] = s
+				hasBefore[i] = true
+			}
 		}
+	}
 
-		// other error, if any
-		return nil, err
+	time.Sleep(interval)
+
+	resampled := make([]procs.Process, len(matches))
+	for i, p := range matches {
+		resampled[i] = p
+
+		if !hasBefore[i] {
+			continue
+		}
+
+		r := p.(cpuSecondsReader)
+		after, err := r.CPUSeconds()
+		if err != nil {
+			continue
+		}
+
+		pcpu := (after - before[i]) / interval.Seconds() * 100
+		resampled[i] = sampledProcess{Process: p, pcpu: pcpu}
 	}
 
-	return newUnixProcess(pid)
+	return resampled
 }
 
-// newUnixProcess is adding pid to the Process type
-// and call Refresh function to fill missing data
-func newUnixProcess(pid int) (*UnixProcess, error) {
-	p := &UnixProcess{pid: pid}
-	return p, p.Refresh()
+// matchesOptions reports whether p satisfies every matcher that was
+// supplied on the command line. Filters that weren't supplied are
+// skipped, so e.g. --user alone with no -c/-r/-C still works.
+func matchesOptions(p procs.Process, opts options) bool {
+	if opts.exact != "" && !matchesCommand(p, opts.exact) {
+		return false
+	}
+
+	if opts.regex != nil && !opts.regex.MatchString(p.Executable()) {
+		return false
+	}
+
+	if opts.cmdlineSubstr != "" {
+		cmdline, err := p.Cmdline()
+		if err != nil || !strings.Contains(strings.Join(cmdline, " "), opts.cmdlineSubstr) {
+			return false
+		}
+	}
+
+	if opts.ppid != unset && p.PPid() != opts.ppid {
+		return false
+	}
+
+	if opts.user != "" {
+		uid, err := p.UID()
+		if err != nil {
+			return false
+		}
+
+		u, err := user.LookupId(strconv.Itoa(uid))
+		if err != nil || u.Username != opts.user {
+			return false
+		}
+	}
+
+	return true
 }
 
-// Refresh reloads all the data associated with this process.
-func (p *UnixProcess) Refresh() error {
-	statPath := fmt.Sprintf("/proc/%d/stat", p.pid)
-	dataBytes, err := ioutil.ReadFile(statPath)
-	if err != nil {
-		return err
+// matchesCommand reports whether command matches either the process's
+// short executable name or its full resolved path. This lets -c match
+// processes whose real name is longer than the 15-byte TASK_COMM_LEN
+// that truncates the short name on Linux.
+func matchesCommand(p procs.Process, command string) bool {
+	if p.Executable() == command {
+		return true
 	}
 
-	// First, parse out the image name
-	data := string(dataBytes)
-	binStart := strings.IndexRune(data, '(') + 1
-	binEnd := strings.IndexRune(data[binStart:], ')')
+	path, err := p.Path()
+	return err == nil && path == command
+}
 
-	// setup name of the proces on to the pointer
-	p.binary = data[binStart : binStart+binEnd]
+// evaluate maps a match count onto a Nagios status code, checking the
+// critical (--min/--max) thresholds before the warning (--warn-min/
+// --warn-max) ones. When no threshold flag was given at all, it falls
+// back to the tool's original behavior: CRITICAL if nothing matched.
+func evaluate(count int, opts options) (code int, label string) {
+	if (opts.min != unset && count < opts.min) || (opts.max != unset && count > opts.max) {
+		return CRITICAL, "CRITICAL"
+	}
 
-	// Move past the image name and start parsing the rest
-	data = data[binStart+binEnd+2:]
+	if (opts.warnMin != unset && count < opts.warnMin) || (opts.warnMax != unset && count > opts.warnMax) {
+		return WARNING, "WARNING"
+	}
 
-	// setup rest of the process types in the pointer
-	// and return error if any
-	_, err = fmt.Sscanf(data,
-		"%c %d %d %d",
-		&p.state,
-		&p.ppid,
-		&p.pgrp,
-		&p.sid)
+	if opts.min == unset && opts.max == unset && opts.warnMin == unset && opts.warnMax == unset && count == 0 {
+		return CRITICAL, "CRITICAL"
+	}
 
-	return err
+	return OK, "OK"
 }
 
-// processes return all unix processes as a struct of Process type
-func processes() ([]Process, error) {
-	d, err := os.Open("/proc")
-	if err != nil {
-		return nil, err
+// describeMatch renders a short human-readable summary of the active
+// matchers, for the result line.
+func describeMatch(opts options) string {
+	var parts []string
+	if opts.exact != "" {
+		parts = append(parts, fmt.Sprintf("-c %q", opts.exact))
+	}
+	if opts.regex != nil {
+		parts = append(parts, fmt.Sprintf("-r %q", opts.regex.String()))
+	}
+	if opts.cmdlineSubstr != "" {
+		parts = append(parts, fmt.Sprintf("-C %q", opts.cmdlineSubstr))
+	}
+	if opts.ppid != unset {
+		parts = append(parts, fmt.Sprintf("--ppid %d", opts.ppid))
+	}
+	if opts.user != "" {
+		parts = append(parts, fmt.Sprintf("--user %s", opts.user))
 	}
-	defer d.Close()
-
-	results := make([]Process, 0, 50)
-	for {
-		// Readdir(10) return slice of first 10 processes
-		// if in for{} going for the next 10 processes till return al of them
-		fis, err := d.Readdir(10)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
 
-		// now we need to iterate over the slice of first 10 processes
-		// we can call their names by .Name() as it's interface FileInfo
-		// we need to use for as we don't know how many processes there is
-		// at the /proc diretory, so it's better to get 10 parse and get another 10
-		for _, fi := range fis {
-			// We only care about directories, since all pids are dirs
-			if !fi.IsDir() {
-				continue
-			}
+	return strings.Join(parts, " ")
+}
 
-			// We only care if the name starts with a numeric
-			name := fi.Name()
-			if name[0] < '0' || name[0] > '9' {
-				continue
-			}
+// printTree reports the aggregated memory/CPU usage of p and all of its
+// descendants, for monitoring workers that fork (e.g. nginx, php-fpm).
+func printTree(p procs.Process) {
+	info := p.ChildrenProcInfo(true)
+	fmt.Printf(", children: %v, rss: %dkb, cpu: %.2f%%", info.Pids, info.Rss/1024, info.PCpu)
+}
 
-			// From this point forward, any errors we just ignore, because
-			// it might simply be that the process doesn't exist anymore.
-			// convert string to int
-			pid, err := strconv.ParseInt(name, 10, 0)
-			if err != nil {
-				continue
-			}
+// perfdata renders matches as a Nagios/Sensu performance data string, so
+// graphing backends (PNP4Nagios, Graphite via Sensu) can plot the match
+// count alongside its thresholds and the summed memory/CPU of the matches.
+func perfdata(matches []procs.Process, opts options) string {
+	var rss uint64
+	var pcpu float64
+	for _, p := range matches {
+		r, _ := p.Rss()
+		rss += r
+
+		c, _ := p.PCpu()
+		pcpu += c
+	}
 
-			p, err := newUnixProcess(int(pid))
-			if err != nil {
-				continue
-			}
+	return fmt.Sprintf("| procs=%d;%s;%s;0; rss=%dkb cpu=%.2f%%",
+		len(matches), thresholdRange(opts.warnMin, opts.warnMax), thresholdRange(opts.min, opts.max), rss/1024, pcpu)
+}
 
-			results = append(results, p)
-		}
+// thresholdRange renders a min/max pair as a Nagios threshold range
+// (e.g. "5:10", "5:" or "10"). It returns "" when neither bound was set.
+func thresholdRange(min, max int) string {
+	switch {
+	case min == unset && max == unset:
+		return ""
+	case max == unset:
+		return fmt.Sprintf("%d:", min)
+	case min == unset:
+		return strconv.Itoa(max)
+	default:
+		return fmt.Sprintf("%d:%d", min, max)
 	}
+}
 
-	return results, nil
+// jsonMatch is a single matched process as rendered by -o json.
+type jsonMatch struct {
+	Pid     int      `json:"pid"`
+	PPid    int      `json:"ppid"`
+	Exe     string   `json:"exe"`
+	Cmdline []string `json:"cmdline"`
+	Rss     uint64   `json:"rss"`
+	PCpu    float64  `json:"pcpu"`
 }
 
-// Main function to actually start programm
-func main() {
-	run, command, err := Command()
+// jsonPerfdata is perfdata as rendered by -o json.
+type jsonPerfdata struct {
+	Procs int     `json:"procs"`
+	Warn  string  `json:"warn"`
+	Crit  string  `json:"crit"`
+	Rss   uint64  `json:"rss"`
+	PCpu  float64 `json:"pcpu"`
+}
+
+// jsonResult is the top-level document rendered by -o json.
+type jsonResult struct {
+	Status   string       `json:"status"`
+	Code     int          `json:"code"`
+	Message  string       `json:"message"`
+	Matches  []jsonMatch  `json:"matches"`
+	Perfdata jsonPerfdata `json:"perfdata"`
+}
+
+// printJSON renders matches as the machine-readable document produced by
+// -o json, mirroring the text output's status line, match list and
+// perfdata.
+func printJSON(matches []procs.Process, opts options, code int, label string) {
+	result := jsonResult{
+		Status:  label,
+		Code:    code,
+		Message: fmt.Sprintf("%s: %d process(es) matching %s", label, len(matches), describeMatch(opts)),
+		Perfdata: jsonPerfdata{
+			Procs: len(matches),
+			Warn:  thresholdRange(opts.warnMin, opts.warnMax),
+			Crit:  thresholdRange(opts.min, opts.max),
+		},
+	}
+
+	for _, p := range matches {
+		cmdline, _ := p.Cmdline()
+		rss, _ := p.Rss()
+		pcpu, _ := p.PCpu()
+
+		result.Matches = append(result.Matches, jsonMatch{
+			Pid:     p.Pid(),
+			PPid:    p.PPid(),
+			Exe:     p.Executable(),
+			Cmdline: cmdline,
+			Rss:     rss,
+			PCpu:    pcpu,
+		})
+
+		result.Perfdata.Rss += rss
+		result.Perfdata.PCpu += pcpu
+	}
+
+	out, err := json.Marshal(result)
 	if err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(UNKNOWN)
 	}
 
-	for _, i := range run {
-		if i.Executable() == command && i.PPid() == 1 {
-			fmt.Printf("Process exist: %v, pid: %d", i.Executable(), i.Pid())
-			os.Exit(OK)
-		}
-	}
-
-	fmt.Printf("Process do not exist: %v", command)
-	os.Exit(CRITICAL)
+	fmt.Println(string(out))
 }
 
-// Command function is checking if we run it with the correct
-// parameters and return process information
-func Command() ([]Process, string, error) {
-	if len(os.Args) != 3 {
+// Command parses os.Args into options. It exits via help() when a flag
+// can't be parsed or no matcher flag was given, and prints an error and
+// exits with UNKNOWN for an invalid -o value or -r regex.
+func Command() options {
+	fs := flag.NewFlagSet("checkProcs", flag.ExitOnError)
+	fs.Usage = help
+
+	exact := fs.String("c", "", "exact executable name match")
+	regex := fs.String("r", "", "regex match against the executable name")
+	cmdline := fs.String("C", "", "substring match against the full command line")
+	ppid := fs.Int("ppid", unset, "require a specific parent pid")
+	userName := fs.String("user", "", "require a specific owning user")
+	min := fs.Int("min", unset, "critical if fewer than this many processes match")
+	max := fs.Int("max", unset, "critical if more than this many processes match")
+	warnMin := fs.Int("warn-min", unset, "warning if fewer than this many processes match")
+	warnMax := fs.Int("warn-max", unset, "warning if more than this many processes match")
+	tree := fs.Bool("tree", false, "also report aggregated memory/CPU of each match and its children")
+	output := fs.String("o", "text", "output format: text or json")
+	cpuSampleInterval := fs.Duration("cpu-sample-interval", 0, "re-sample %CPU after this pause before reporting it (e.g. 1s)")
+
+	fs.Parse(os.Args[1:])
+
+	if *exact == "" && *regex == "" && *cmdline == "" && *ppid == unset && *userName == "" {
 		help()
 	}
-	if os.Args[1] != "-c" {
-		help()
+
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Error: invalid -o %q: must be \"text\" or \"json\"\n", *output)
+		os.Exit(UNKNOWN)
 	}
 
-	command := os.Args[2]
-	if command == "" {
-		help()
+	opts := options{
+		exact:             *exact,
+		cmdlineSubstr:     *cmdline,
+		ppid:              *ppid,
+		user:              *userName,
+		min:               *min,
+		max:               *max,
+		warnMin:           *warnMin,
+		warnMax:           *warnMax,
+		tree:              *tree,
+		output:            *output,
+		cpuSampleInterval: *cpuSampleInterval,
 	}
 
-	procs, err := Processes()
-	if err != nil {
-		return nil, "", err
+	if *regex != "" {
+		re, err := regexp.Compile(*regex)
+		if err != nil {
+			fmt.Printf("Error: invalid -r regex: %v\n", err)
+			os.Exit(UNKNOWN)
+		}
+		opts.regex = re
 	}
-	return procs, command, nil
+
+	return opts
 }
 
 // help function to show help how to execute
@@ -250,10 +431,29 @@ func Command() ([]Process, string, error) {
 func help() {
 	fmt.Println("")
 	fmt.Println("  -c string")
-	fmt.Println("    	process name (string)")
+	fmt.Println("    	exact executable name match")
+	fmt.Println("  -r string")
+	fmt.Println("    	regex match against the executable name")
+	fmt.Println("  -C string")
+	fmt.Println("    	substring match against the full command line")
+	fmt.Println("  --ppid int")
+	fmt.Println("    	require a specific parent pid")
+	fmt.Println("  --user string")
+	fmt.Println("    	require a specific owning user")
+	fmt.Println("  --min, --max int")
+	fmt.Println("    	critical if the match count falls outside this range")
+	fmt.Println("  --warn-min, --warn-max int")
+	fmt.Println("    	warning if the match count falls outside this range")
+	fmt.Println("  -tree")
+	fmt.Println("    	also report aggregated memory/CPU of each match and its children")
+	fmt.Println("  -o string")
+	fmt.Println("    	output format: text or json (default \"text\")")
+	fmt.Println("  --cpu-sample-interval duration")
+	fmt.Println("    	re-sample %CPU after this pause before reporting it (e.g. 1s)")
 	fmt.Println("")
 	fmt.Println("  example:")
 	fmt.Println("       ./check_proc -c \"sshd\"")
+	fmt.Println("       ./check_proc -r \"^php-fpm\" --min 1 --max 20 -tree")
 	fmt.Println("")
 	os.Exit(UNKNOWN)
 }
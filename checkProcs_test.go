@@ -0,0 +1,101 @@
+package main
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     int
+		opts      options
+		wantCode  int
+		wantLabel string
+	}{
+		{
+			name:      "no thresholds, no matches is critical",
+			count:     0,
+			opts:      options{min: unset, max: unset, warnMin: unset, warnMax: unset},
+			wantCode:  CRITICAL,
+			wantLabel: "CRITICAL",
+		},
+		{
+			name:      "no thresholds, at least one match is ok",
+			count:     1,
+			opts:      options{min: unset, max: unset, warnMin: unset, warnMax: unset},
+			wantCode:  OK,
+			wantLabel: "OK",
+		},
+		{
+			name:      "below critical min",
+			count:     1,
+			opts:      options{min: 2, max: unset, warnMin: unset, warnMax: unset},
+			wantCode:  CRITICAL,
+			wantLabel: "CRITICAL",
+		},
+		{
+			name:      "above critical max",
+			count:     21,
+			opts:      options{min: unset, max: 20, warnMin: unset, warnMax: unset},
+			wantCode:  CRITICAL,
+			wantLabel: "CRITICAL",
+		},
+		{
+			name:      "below warning min but within critical min",
+			count:     2,
+			opts:      options{min: 1, max: unset, warnMin: 3, warnMax: unset},
+			wantCode:  WARNING,
+			wantLabel: "WARNING",
+		},
+		{
+			name:      "above warning max but within critical max",
+			count:     15,
+			opts:      options{min: unset, max: 20, warnMin: unset, warnMax: 10},
+			wantCode:  WARNING,
+			wantLabel: "WARNING",
+		},
+		{
+			name:      "critical takes priority over warning",
+			count:     25,
+			opts:      options{min: unset, max: 20, warnMin: unset, warnMax: 10},
+			wantCode:  CRITICAL,
+			wantLabel: "CRITICAL",
+		},
+		{
+			name:      "within every threshold is ok",
+			count:     5,
+			opts:      options{min: 1, max: 20, warnMin: 2, warnMax: 10},
+			wantCode:  OK,
+			wantLabel: "OK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, label := evaluate(tt.count, tt.opts)
+			if code != tt.wantCode || label != tt.wantLabel {
+				t.Errorf("evaluate(%d, %+v) = %d, %q, want %d, %q",
+					tt.count, tt.opts, code, label, tt.wantCode, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestThresholdRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int
+		want     string
+	}{
+		{"neither set", unset, unset, ""},
+		{"only min", 5, unset, "5:"},
+		{"only max", unset, 10, "10"},
+		{"both set", 5, 10, "5:10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholdRange(tt.min, tt.max); got != tt.want {
+				t.Errorf("thresholdRange(%d, %d) = %q, want %q", tt.min, tt.max, got, tt.want)
+			}
+		})
+	}
+}
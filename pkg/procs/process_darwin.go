@@ -0,0 +1,185 @@
+//go:build darwin
+// +build darwin
+
+package procs
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// DarwinProcess is the macOS implementation of Process, backed by the
+// kern.proc.all sysctl (KERN_PROC_ALL), which returns one kinfo_proc per
+// process without requiring any special privileges.
+type DarwinProcess struct {
+	pid    int
+	ppid   int
+	binary string
+	uid    int
+}
+
+// Pid simply return pid of the specific process
+func (p *DarwinProcess) Pid() int {
+	return p.pid
+}
+
+// PPid simply return ppid of the specific process
+func (p *DarwinProcess) PPid() int {
+	return p.ppid
+}
+
+// Executable simply return name of the process. kinfo_proc's p_comm is,
+// like Linux's TASK_COMM_LEN, truncated (MAXCOMLEN, 16 bytes); Path
+// resolves the untruncated name where possible.
+func (p *DarwinProcess) Executable() string {
+	if path, err := p.Path(); err == nil {
+		return filepath.Base(path)
+	}
+
+	return p.binary
+}
+
+// Path resolves the full path to the executable backing this process via
+// the KERN_PROCARGS2 sysctl, which (unlike kinfo_proc) carries the
+// original exec path.
+func (p *DarwinProcess) Path() (string, error) {
+	execPath, _, err := procArgv(p.pid)
+	if err != nil {
+		return "", err
+	}
+	if execPath == "" {
+		return "", fmt.Errorf("procs: no exec path for pid %d", p.pid)
+	}
+
+	return execPath, nil
+}
+
+// Rss is not supported on Darwin: kinfo_proc's embedded vmspace
+// (kp_eproc.e_vm) is no longer populated by the kernel for userspace
+// callers, and x/sys/unix.Vmspace models it as a placeholder with no
+// usable fields. Getting RSS requires proc_pidinfo/libproc (cgo).
+func (p *DarwinProcess) Rss() (uint64, error) {
+	return 0, errUnsupported("Rss")
+}
+
+// Cmdline returns the process's argv, read via the KERN_PROCARGS2
+// sysctl.
+func (p *DarwinProcess) Cmdline() ([]string, error) {
+	_, argv, err := procArgv(p.pid)
+	return argv, err
+}
+
+// UID returns the numeric ID of the user owning this process, taken from
+// kinfo_proc's embedded credentials.
+func (p *DarwinProcess) UID() (int, error) {
+	return p.uid, nil
+}
+
+// PCpu is not supported on Darwin: unlike Linux and the BSDs, kinfo_proc
+// does not carry per-process CPU ticks, which instead requires sampling
+// via the libproc/proc_pid_rusage API (cgo).
+func (p *DarwinProcess) PCpu() (float64, error) {
+	return 0, errUnsupported("PCpu")
+}
+
+// Children returns the processes whose parent is p. When recursive is
+// true, descendants of those children are folded in too.
+func (p *DarwinProcess) Children(recursive bool) []Process {
+	return childrenOf(p.pid, recursive)
+}
+
+// ChildrenProcInfo aggregates Rss and PCpu across p and p.Children(recursive).
+func (p *DarwinProcess) ChildrenProcInfo(recursive bool) ProcInfo {
+	return childrenProcInfoOf(p, recursive)
+}
+
+// findProcess looks up a single pid via the per-pid form of the
+// kern.proc sysctl.
+func findProcess(pid int) (Process, error) {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		if err == unix.ESRCH {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return newDarwinProcess(kp), nil
+}
+
+// processes returns every process visible to the caller via
+// kern.proc.all (KERN_PROC_ALL).
+func processes() ([]Process, error) {
+	kprocs, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Process, 0, len(kprocs))
+	for i := range kprocs {
+		results = append(results, newDarwinProcess(&kprocs[i]))
+	}
+
+	return results, nil
+}
+
+// newDarwinProcess converts a kinfo_proc, as returned by the kern.proc
+// sysctl family, into a DarwinProcess.
+func newDarwinProcess(kp *unix.KinfoProc) *DarwinProcess {
+	comm := kp.Proc.P_comm[:]
+	if idx := bytes.IndexByte(comm, 0); idx >= 0 {
+		comm = comm[:idx]
+	}
+
+	return &DarwinProcess{
+		pid:    int(kp.Proc.P_pid),
+		ppid:   int(kp.Eproc.Ppid),
+		binary: string(comm),
+		uid:    int(kp.Eproc.Ucred.Uid),
+	}
+}
+
+// procArgv reads the original exec path and argv for pid via
+// KERN_PROCARGS2, which kinfo_proc itself does not expose. The buffer is
+// laid out as: argc (4 bytes), the exec path, then argc NUL-terminated
+// argv strings (padded with extra NULs in between).
+func procArgv(pid int) (string, []string, error) {
+	data, err := unix.SysctlRaw("kern.procargs2", pid)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("procs: short procargs2 for pid %d", pid)
+	}
+
+	argc := int(data[0]) | int(data[1])<<8 | int(data[2])<<16 | int(data[3])<<24
+	rest := data[4:]
+
+	// Read the exec path.
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return "", nil, fmt.Errorf("procs: malformed procargs2 for pid %d", pid)
+	}
+	execPath := string(rest[:end])
+	rest = rest[end:]
+
+	// Skip the NUL padding that follows the exec path.
+	for len(rest) > 0 && rest[0] == 0 {
+		rest = rest[1:]
+	}
+
+	argv := make([]string, 0, argc)
+	for i := 0; i < argc && len(rest) > 0; i++ {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			break
+		}
+		argv = append(argv, string(rest[:end]))
+		rest = rest[end+1:]
+	}
+
+	return execPath, argv, nil
+}
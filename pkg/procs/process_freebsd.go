@@ -0,0 +1,256 @@
+//go:build freebsd
+// +build freebsd
+
+package procs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// fixedPointScale is FreeBSD's FSCALE: kinfo_proc reports %CPU as a
+// fixed-point fraction with this many bits of precision.
+const fixedPointScale = 1 << 11
+
+// kinfoProc mirrors the leading fields of struct kinfo_proc (sys/user.h)
+// that this package needs. x/sys/unix has no FreeBSD binding for this
+// struct (its kinfo_proc support is Darwin-only), so kern.proc sysctls
+// are decoded by hand here, the same way process_solaris.go decodes
+// psinfo_t. Only the leading portion up to ki_comm is modeled; each
+// record's own ki_structsize (read separately, see sysctlKinfoProcs) is
+// used as its true stride, since the kernel's struct is versioned and
+// may carry trailing fields this prefix doesn't know about.
+type kinfoProc struct {
+	Structsize int32
+	Layout     int32
+	_          [8]uint64 // ki_args, ki_paddr, ki_addr, ki_tracep, ki_textvp, ki_fd, ki_vmspace, ki_wchan
+	Pid        int32
+	Ppid       int32
+	Pgid       int32
+	Tpgid      int32
+	Sid        int32
+	Tsid       int32
+	_          int16      // ki_jobc
+	_          int16      // spare
+	_          uint32     // ki_tdev
+	_          [16]uint32 // ki_siglist, ki_sigmask, ki_sigignore, ki_sigcatch (sigset_t x4)
+	Uid        uint32
+	Ruid       uint32
+	Svuid      uint32
+	Rgid       uint32
+	Svgid      uint32
+	_          int16      // ki_ngroups
+	_          int16      // spare
+	_          [16]uint32 // ki_groups (KI_NGROUPS)
+	_          uint64     // ki_size, vm_size_t
+	Rssize     int64      // ki_rssize, segsz_t, in pages
+	_          int64      // ki_swrss
+	_          int64      // ki_tsize
+	_          int64      // ki_dsize
+	_          int64      // ki_ssize
+	_          uint16     // ki_xstat
+	_          uint16     // ki_acflag
+	Pctcpu     uint32     // ki_pctcpu, fixpt_t
+	_          uint32     // ki_estcpu
+	_          uint32     // ki_slptime
+	_          uint32     // ki_swtime
+	_          uint32     // ki_cow
+	_          uint64     // ki_runtime
+	_          [2]int64   // ki_start (timeval)
+	_          [2]int64   // ki_childtime (timeval)
+	_          int64      // ki_flag
+	_          int64      // ki_kiflag
+	_          int32      // ki_traceflag
+	_          byte       // ki_stat
+	_          int8       // ki_nice
+	_          byte       // ki_lock
+	_          byte       // ki_rqindex
+	_          byte       // ki_oncpu_old
+	_          byte       // ki_lastcpu_old
+	_          [17]byte   // ki_tdname, TDNAMLEN+1
+	_          [9]byte    // ki_wmesg, WMESGLEN+1
+	_          [18]byte   // ki_login, LOGNAMELEN+1
+	_          [9]byte    // ki_lockname, LOCKNAMELEN+1
+	Comm       [20]byte   // ki_comm, COMMLEN+1
+}
+
+// FreeBSDProcess is the FreeBSD implementation of Process, backed by the
+// kern.proc.all sysctl (KERN_PROC_ALL), whose kinfo_proc already carries
+// RSS and %CPU directly - no /proc mount required.
+type FreeBSDProcess struct {
+	pid    int
+	ppid   int
+	binary string
+	path   string
+	uid    int
+
+	rssPages uint64
+	pctCPU   int32
+}
+
+// Pid simply return pid of the specific process
+func (p *FreeBSDProcess) Pid() int {
+	return p.pid
+}
+
+// PPid simply return ppid of the specific process
+func (p *FreeBSDProcess) PPid() int {
+	return p.ppid
+}
+
+// Executable simply return name of the process.
+func (p *FreeBSDProcess) Executable() string {
+	if path, err := p.Path(); err == nil {
+		return filepath.Base(path)
+	}
+
+	return p.binary
+}
+
+// Path returns the full path to the executable backing this process, as
+// resolved by the kern.proc.pathname sysctl.
+func (p *FreeBSDProcess) Path() (string, error) {
+	if p.path != "" {
+		return p.path, nil
+	}
+
+	return unix.SysctlArgs("kern.proc.pathname", p.pid)
+}
+
+// Rss returns the resident set size of this process, in bytes, from
+// kinfo_proc's ki_rssize (pages).
+func (p *FreeBSDProcess) Rss() (uint64, error) {
+	return p.rssPages * uint64(unix.Getpagesize()), nil
+}
+
+// PCpu returns this process's CPU usage as a percentage, decoded from
+// kinfo_proc's fixed-point ki_pctcpu.
+func (p *FreeBSDProcess) PCpu() (float64, error) {
+	return float64(p.pctCPU) / fixedPointScale * 100, nil
+}
+
+// Cmdline returns the process's argv, read via the kern.proc.args
+// sysctl, which returns it as NUL-separated strings directly.
+func (p *FreeBSDProcess) Cmdline() ([]string, error) {
+	data, err := unix.SysctlRaw("kern.proc.args", p.pid)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0})
+	cmdline := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		cmdline = append(cmdline, string(part))
+	}
+
+	return cmdline, nil
+}
+
+// UID returns the numeric ID of the user owning this process, from
+// kinfo_proc's ki_uid.
+func (p *FreeBSDProcess) UID() (int, error) {
+	return p.uid, nil
+}
+
+// Children returns the processes whose parent is p. When recursive is
+// true, descendants of those children are folded in too.
+func (p *FreeBSDProcess) Children(recursive bool) []Process {
+	return childrenOf(p.pid, recursive)
+}
+
+// ChildrenProcInfo aggregates Rss and PCpu across p and p.Children(recursive).
+func (p *FreeBSDProcess) ChildrenProcInfo(recursive bool) ProcInfo {
+	return childrenProcInfoOf(p, recursive)
+}
+
+// findProcess looks up a single pid via the per-pid form of the
+// kern.proc sysctl.
+func findProcess(pid int) (Process, error) {
+	kprocs, err := sysctlKinfoProcs("kern.proc.pid", pid)
+	if err != nil {
+		if err == unix.ESRCH {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(kprocs) == 0 {
+		return nil, nil
+	}
+
+	return newFreeBSDProcess(&kprocs[0]), nil
+}
+
+// processes returns every process visible to the caller via
+// kern.proc.all (KERN_PROC_ALL).
+func processes() ([]Process, error) {
+	kprocs, err := sysctlKinfoProcs("kern.proc.all")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Process, 0, len(kprocs))
+	for i := range kprocs {
+		results = append(results, newFreeBSDProcess(&kprocs[i]))
+	}
+
+	return results, nil
+}
+
+// sysctlKinfoProcs runs the kern.proc sysctl named by mib (with args
+// appended to the MIB, e.g. a pid) and decodes the result as a sequence
+// of kinfo_proc records. Each record's own leading ki_structsize field
+// gives its true length, so a kernel whose kinfo_proc is longer than the
+// prefix kinfoProc models still gets sliced at the right boundary.
+func sysctlKinfoProcs(mib string, args ...int) ([]kinfoProc, error) {
+	raw, err := unix.SysctlRaw(mib, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []kinfoProc
+	for len(raw) > 0 {
+		if len(raw) < 4 {
+			return nil, fmt.Errorf("procs: truncated kinfo_proc record from %q", mib)
+		}
+
+		stride := int(binary.LittleEndian.Uint32(raw[:4]))
+		if stride <= 0 || stride > len(raw) {
+			return nil, fmt.Errorf("procs: invalid kinfo_proc record size %d from %q", stride, mib)
+		}
+
+		var kp kinfoProc
+		if err := binary.Read(bytes.NewReader(raw[:stride]), binary.LittleEndian, &kp); err != nil {
+			return nil, err
+		}
+		results = append(results, kp)
+
+		raw = raw[stride:]
+	}
+
+	return results, nil
+}
+
+// newFreeBSDProcess converts a kinfo_proc, as returned by the kern.proc
+// sysctl family, into a FreeBSDProcess.
+func newFreeBSDProcess(kp *kinfoProc) *FreeBSDProcess {
+	comm := kp.Comm[:]
+	if idx := bytes.IndexByte(comm, 0); idx >= 0 {
+		comm = comm[:idx]
+	}
+
+	return &FreeBSDProcess{
+		pid:      int(kp.Pid),
+		ppid:     int(kp.Ppid),
+		binary:   string(comm),
+		uid:      int(kp.Uid),
+		rssPages: uint64(kp.Rssize),
+		pctCPU:   int32(kp.Pctcpu),
+	}
+}
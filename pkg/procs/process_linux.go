@@ -0,0 +1,433 @@
+//go:build linux
+// +build linux
+
+package procs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// clockTicksPerSecond is the kernel's USER_HZ (sysconf(_SC_CLK_TCK)), used
+// to convert the tick-based fields of /proc/<pid>/stat into seconds. 100
+// is the near-universal value on Linux.
+const clockTicksPerSecond = 100
+
+// UnixProcess is the Linux implementation of Process, backed by /proc.
+type UnixProcess struct {
+	pid   int
+	ppid  int
+	state rune
+	pgrp  int
+	sid   int
+
+	binary string
+}
+
+// Pid simply return pid of the specific process
+func (p *UnixProcess) Pid() int {
+	return p.pid
+}
+
+// PPid simply return ppid of the specific process
+func (p *UnixProcess) PPid() int {
+	return p.ppid
+}
+
+// Executable simply return name of the process.
+//
+// /proc/<pid>/stat truncates the image name at TASK_COMM_LEN (15 bytes),
+// so long binary names (e.g. "my-long-daemon-name") come back chopped.
+// Prefer the name resolved from the /proc/<pid>/exe symlink and only
+// fall back to the truncated stat name when that can't be resolved.
+func (p *UnixProcess) Executable() string {
+	if path, err := p.Path(); err == nil {
+		return filepath.Base(path)
+	}
+
+	return p.binary
+}
+
+// Path resolves the full path to the executable backing this process via
+// the /proc/<pid>/exe symlink.
+func (p *UnixProcess) Path() (string, error) {
+	exePath := fmt.Sprintf("/proc/%d/exe", p.pid)
+	return filepath.EvalSymlinks(exePath)
+}
+
+// Rss returns the resident set size of this process, in bytes, read from
+// the second field of /proc/<pid>/statm (measured in pages).
+func (p *UnixProcess) Rss() (uint64, error) {
+	statmPath := fmt.Sprintf("/proc/%d/statm", p.pid)
+	dataBytes, err := ioutil.ReadFile(statmPath)
+	if err != nil {
+		return 0, err
+	}
+
+	rssPages, err := parseStatmRssPages(dataBytes)
+	if err != nil {
+		return 0, fmt.Errorf("procs: %w for pid %d", err, p.pid)
+	}
+
+	return rssPages * uint64(os.Getpagesize()), nil
+}
+
+// parseStatmRssPages parses the second field (resident set size, in
+// pages) out of the contents of /proc/<pid>/statm.
+func parseStatmRssPages(data []byte) (uint64, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected statm format")
+	}
+
+	return strconv.ParseUint(fields[1], 10, 64)
+}
+
+// PCpu returns this process's CPU usage as a percentage of a single core,
+// averaged over its entire lifetime: cumulative CPU time (utime+stime from
+// /proc/<pid>/stat) divided by the process's age (from /proc/uptime).
+func (p *UnixProcess) PCpu() (float64, error) {
+	cpuSeconds, err := p.CPUSeconds()
+	if err != nil {
+		return 0, err
+	}
+
+	age, err := p.ageSeconds()
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, nil
+	}
+
+	return cpuSeconds / age * 100, nil
+}
+
+// CPUSeconds returns this process's cumulative CPU time (utime+stime
+// from /proc/<pid>/stat), in seconds. Unlike PCpu, which divides this by
+// the process's whole-lifetime age, CPUSeconds lets a caller take two
+// readings some interval apart and diff them into a windowed rate (see
+// checkProcs' --cpu-sample-interval).
+func (p *UnixProcess) CPUSeconds() (float64, error) {
+	ticks, err := p.cpuTicks()
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(ticks) / float64(clockTicksPerSecond), nil
+}
+
+// Cmdline returns the process's argv, read from the NUL-separated
+// /proc/<pid>/cmdline.
+func (p *UnixProcess) Cmdline() ([]string, error) {
+	cmdlinePath := fmt.Sprintf("/proc/%d/cmdline", p.pid)
+	dataBytes, err := ioutil.ReadFile(cmdlinePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCmdline(dataBytes), nil
+}
+
+// parseCmdline splits the NUL-separated contents of /proc/<pid>/cmdline
+// into its argv entries, dropping the trailing empty element a NUL
+// terminator leaves behind.
+func parseCmdline(data []byte) []string {
+	parts := bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0})
+	cmdline := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		cmdline = append(cmdline, string(part))
+	}
+
+	return cmdline
+}
+
+// UID returns the numeric ID of the user owning this process, read from
+// the "Uid:" line of /proc/<pid>/status.
+func (p *UnixProcess) UID() (int, error) {
+	statusPath := fmt.Sprintf("/proc/%d/status", p.pid)
+	dataBytes, err := ioutil.ReadFile(statusPath)
+	if err != nil {
+		return 0, err
+	}
+
+	uid, err := parseStatusUID(dataBytes)
+	if err != nil {
+		return 0, fmt.Errorf("procs: %w for pid %d", err, p.pid)
+	}
+
+	return uid, nil
+}
+
+// parseStatusUID extracts the real UID (the first value on the "Uid:"
+// line) out of the contents of /proc/<pid>/status.
+func parseStatusUID(data []byte) (int, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("unexpected Uid line in status")
+		}
+
+		return strconv.Atoi(fields[1])
+	}
+
+	return 0, fmt.Errorf("no Uid field in status")
+}
+
+// Children returns the processes whose parent is p. When recursive is
+// true, descendants of those children are folded in too.
+func (p *UnixProcess) Children(recursive bool) []Process {
+	return childrenOf(p.pid, recursive)
+}
+
+// ChildrenProcInfo aggregates Rss and PCpu across p and p.Children(recursive).
+func (p *UnixProcess) ChildrenProcInfo(recursive bool) ProcInfo {
+	return childrenProcInfoOf(p, recursive)
+}
+
+// cpuTicks returns the cumulative utime+stime (fields 14 and 15 of
+// /proc/<pid>/stat) for this process, in clock ticks.
+func (p *UnixProcess) cpuTicks() (uint64, error) {
+	fields, err := p.statFields()
+	if err != nil {
+		return 0, err
+	}
+
+	return cpuTicksFromStatFields(fields)
+}
+
+// cpuTicksFromStatFields sums utime+stime (fields 14 and 15 of
+// /proc/<pid>/stat, i.e. index 11 and 12 of statFields' "state"-indexed
+// slice) out of the fields returned by statFields.
+func cpuTicksFromStatFields(fields []string) (uint64, error) {
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected stat format")
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return utime + stime, nil
+}
+
+// ageSeconds returns how long this process has been running, derived from
+// its starttime (field 22 of /proc/<pid>/stat) and the system uptime.
+func (p *UnixProcess) ageSeconds() (float64, error) {
+	fields, err := p.statFields()
+	if err != nil {
+		return 0, err
+	}
+
+	startSeconds, err := startSecondsFromStatFields(fields)
+	if err != nil {
+		return 0, err
+	}
+
+	uptimeBytes, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+
+	uptimeSeconds, err := parseUptimeSeconds(uptimeBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	return uptimeSeconds - startSeconds, nil
+}
+
+// startSecondsFromStatFields converts starttime (field 22 of
+// /proc/<pid>/stat, i.e. index 19 of statFields' "state"-indexed slice)
+// from clock ticks since boot into seconds.
+func startSecondsFromStatFields(fields []string) (float64, error) {
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("unexpected stat format")
+	}
+
+	startTicks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(startTicks) / float64(clockTicksPerSecond), nil
+}
+
+// parseUptimeSeconds parses the first field of /proc/uptime, the number
+// of seconds the system has been up.
+func parseUptimeSeconds(data []byte) (float64, error) {
+	var uptimeSeconds float64
+	if _, err := fmt.Sscanf(string(data), "%f", &uptimeSeconds); err != nil {
+		return 0, err
+	}
+
+	return uptimeSeconds, nil
+}
+
+// statFields returns the whitespace-separated fields of /proc/<pid>/stat
+// that follow the "(comm)" portion, indexed from 0 at "state" (field 3 in
+// proc(5)'s numbering).
+func (p *UnixProcess) statFields() ([]string, error) {
+	statPath := fmt.Sprintf("/proc/%d/stat", p.pid)
+	dataBytes, err := ioutil.ReadFile(statPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := splitStatFields(string(dataBytes))
+	if err != nil {
+		return nil, fmt.Errorf("procs: %w for pid %d", err, p.pid)
+	}
+
+	return fields, nil
+}
+
+// splitStatFields strips the leading "pid (comm)" portion of a
+// /proc/<pid>/stat line (comm may itself contain spaces or parens, so it
+// can't be split on whitespace) and splits what follows into fields,
+// indexed from 0 at "state" (field 3 in proc(5)'s numbering).
+func splitStatFields(line string) ([]string, error) {
+	binStart := strings.IndexRune(line, '(') + 1
+	binEnd := strings.LastIndex(line, ")")
+	if binStart == 0 || binEnd < binStart {
+		return nil, fmt.Errorf("unexpected stat format")
+	}
+	line = line[binEnd+1:]
+
+	fields := strings.Fields(line)
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("unexpected stat format")
+	}
+
+	return fields, nil
+}
+
+// findProcess is returning all information about the specific process
+func findProcess(pid int) (Process, error) {
+	dir := fmt.Sprintf("/proc/%d", pid)
+	_, err := os.Stat(dir)
+	if err != nil {
+		// file does not exist
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		// other error, if any
+		return nil, err
+	}
+
+	return newUnixProcess(pid)
+}
+
+// newUnixProcess is adding pid to the Process type
+// and call Refresh function to fill missing data
+func newUnixProcess(pid int) (*UnixProcess, error) {
+	p := &UnixProcess{pid: pid}
+	return p, p.Refresh()
+}
+
+// Refresh reloads all the data associated with this process.
+func (p *UnixProcess) Refresh() error {
+	statPath := fmt.Sprintf("/proc/%d/stat", p.pid)
+	dataBytes, err := ioutil.ReadFile(statPath)
+	if err != nil {
+		return err
+	}
+
+	// First, parse out the image name
+	data := string(dataBytes)
+	binStart := strings.IndexRune(data, '(') + 1
+	binEnd := strings.IndexRune(data[binStart:], ')')
+
+	// setup name of the proces on to the pointer
+	p.binary = data[binStart : binStart+binEnd]
+
+	// Move past the image name and start parsing the rest
+	data = data[binStart+binEnd+2:]
+
+	// setup rest of the process types in the pointer
+	// and return error if any
+	_, err = fmt.Sscanf(data,
+		"%c %d %d %d",
+		&p.state,
+		&p.ppid,
+		&p.pgrp,
+		&p.sid)
+
+	return err
+}
+
+// processes return all unix processes as a struct of Process type
+func processes() ([]Process, error) {
+	d, err := os.Open("/proc")
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	results := make([]Process, 0, 50)
+	for {
+		// Readdir(10) return slice of first 10 processes
+		// if in for{} going for the next 10 processes till return al of them
+		fis, err := d.Readdir(10)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// now we need to iterate over the slice of first 10 processes
+		// we can call their names by .Name() as it's interface FileInfo
+		// we need to use for as we don't know how many processes there is
+		// at the /proc diretory, so it's better to get 10 parse and get another 10
+		for _, fi := range fis {
+			// We only care about directories, since all pids are dirs
+			if !fi.IsDir() {
+				continue
+			}
+
+			// We only care if the name starts with a numeric
+			name := fi.Name()
+			if name[0] < '0' || name[0] > '9' {
+				continue
+			}
+
+			// From this point forward, any errors we just ignore, because
+			// it might simply be that the process doesn't exist anymore.
+			// convert string to int
+			pid, err := strconv.ParseInt(name, 10, 0)
+			if err != nil {
+				continue
+			}
+
+			p, err := newUnixProcess(int(pid))
+			if err != nil {
+				continue
+			}
+
+			results = append(results, p)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,171 @@
+//go:build linux
+// +build linux
+
+package procs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatmRssPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    uint64
+		wantErr bool
+	}{
+		{"typical statm", "1234 567 89 1 0 200 0\n", 567, false},
+		{"too few fields", "1234\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatmRssPages([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatmRssPages(%q) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parseStatmRssPages(%q) = %d, want %d", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCmdline(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"typical cmdline", "nginx\x00-g\x00daemon off;\x00", []string{"nginx", "-g", "daemon off;"}},
+		{"trailing nul only", "sshd\x00", []string{"sshd"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCmdline([]byte(tt.data))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCmdline(%q) = %#v, want %#v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatusUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "typical status",
+			data:    "Name:\tsshd\nState:\tS (sleeping)\nUid:\t0\t0\t0\t0\nGid:\t0\t0\t0\t0\n",
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:    "non-root uid",
+			data:    "Name:\tnginx\nUid:\t1000\t1000\t1000\t1000\n",
+			want:    1000,
+			wantErr: false,
+		},
+		{
+			name:    "no Uid line",
+			data:    "Name:\tsshd\nState:\tS (sleeping)\n",
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatusUID([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatusUID(%q) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parseStatusUID(%q) = %d, want %d", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitStatFields(t *testing.T) {
+	// A realistic /proc/<pid>/stat line, truncated to 23 fields after comm,
+	// a few past the 20 splitStatFields requires. comm deliberately contains a space, the
+	// case that rules out splitting on whitespace directly.
+	line := "1234 (my long proc) S 1 1234 1234 0 -1 4194304 100 0 0 0 50 10 0 0 20 0 1 0 5000 0 0 18446744073709551615"
+
+	fields, err := splitStatFields(line)
+	if err != nil {
+		t.Fatalf("splitStatFields(%q) error = %v", line, err)
+	}
+
+	// fields[0] is "state" (proc(5) field 3); utime/stime are fields[11]/[12].
+	if fields[0] != "S" {
+		t.Errorf("fields[0] = %q, want %q", fields[0], "S")
+	}
+	if fields[11] != "50" || fields[12] != "10" {
+		t.Errorf("fields[11], fields[12] = %q, %q, want %q, %q", fields[11], fields[12], "50", "10")
+	}
+
+	if _, err := splitStatFields("no parens here"); err == nil {
+		t.Error("splitStatFields(no parens) expected an error, got nil")
+	}
+
+	if _, err := splitStatFields("1 (short) S 1 2"); err == nil {
+		t.Error("splitStatFields(too few fields) expected an error, got nil")
+	}
+}
+
+func TestCpuTicksFromStatFields(t *testing.T) {
+	fields, err := splitStatFields("1234 (sshd) S 1 1234 1234 0 -1 4194304 100 0 0 0 50 10 0 0 20 0 1 0 5000 0 0 18446744073709551615")
+	if err != nil {
+		t.Fatalf("splitStatFields: %v", err)
+	}
+
+	ticks, err := cpuTicksFromStatFields(fields)
+	if err != nil {
+		t.Fatalf("cpuTicksFromStatFields: %v", err)
+	}
+	if ticks != 60 {
+		t.Errorf("cpuTicksFromStatFields = %d, want 60", ticks)
+	}
+
+	if _, err := cpuTicksFromStatFields(fields[:5]); err == nil {
+		t.Error("cpuTicksFromStatFields(too few fields) expected an error, got nil")
+	}
+}
+
+func TestStartSecondsFromStatFields(t *testing.T) {
+	fields, err := splitStatFields("1234 (sshd) S 1 1234 1234 0 -1 4194304 100 0 0 0 50 10 0 0 20 0 1 0 5000 0 0 18446744073709551615")
+	if err != nil {
+		t.Fatalf("splitStatFields: %v", err)
+	}
+
+	start, err := startSecondsFromStatFields(fields)
+	if err != nil {
+		t.Fatalf("startSecondsFromStatFields: %v", err)
+	}
+
+	want := 5000.0 / float64(clockTicksPerSecond)
+	if start != want {
+		t.Errorf("startSecondsFromStatFields = %v, want %v", start, want)
+	}
+}
+
+func TestParseUptimeSeconds(t *testing.T) {
+	got, err := parseUptimeSeconds([]byte("12345.67 54321.00\n"))
+	if err != nil {
+		t.Fatalf("parseUptimeSeconds: %v", err)
+	}
+	if got != 12345.67 {
+		t.Errorf("parseUptimeSeconds = %v, want %v", got, 12345.67)
+	}
+}
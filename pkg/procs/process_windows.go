@@ -0,0 +1,239 @@
+//go:build windows
+// +build windows
+
+package procs
+
+import (
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// psapi.dll's GetProcessMemoryInfo has no x/sys/windows binding, so it's
+// called directly via a lazy DLL handle, the same pattern x/sys itself
+// uses internally for APIs it hasn't wrapped.
+var (
+	modpsapi                 = windows.NewLazySystemDLL("psapi.dll")
+	procGetProcessMemoryInfo = modpsapi.NewProc("GetProcessMemoryInfo")
+)
+
+// processMemoryCounters mirrors PROCESS_MEMORY_COUNTERS (psapi.h).
+type processMemoryCounters struct {
+	cb                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// WindowsProcess is the Windows implementation of Process, backed by a
+// CreateToolhelp32Snapshot(TH32CS_SNAPPROCESS, ...) walk.
+type WindowsProcess struct {
+	pid    int
+	ppid   int
+	binary string
+}
+
+// Pid simply return pid of the specific process
+func (p *WindowsProcess) Pid() int {
+	return p.pid
+}
+
+// PPid simply return ppid of the specific process
+func (p *WindowsProcess) PPid() int {
+	return p.ppid
+}
+
+// Executable simply return name of the process.
+func (p *WindowsProcess) Executable() string {
+	if path, err := p.Path(); err == nil {
+		return filepath.Base(path)
+	}
+
+	return p.binary
+}
+
+// Path resolves the full path to the executable backing this process via
+// QueryFullProcessImageName, which (unlike the toolhelp snapshot) returns
+// the full path rather than just the file name.
+func (p *WindowsProcess) Path() (string, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(p.pid))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return "", err
+	}
+
+	return windows.UTF16ToString(buf[:size]), nil
+}
+
+// Rss returns the resident set size (working set size) of this process,
+// in bytes, via psapi's GetProcessMemoryInfo.
+func (p *WindowsProcess) Rss() (uint64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION|windows.PROCESS_VM_READ, false, uint32(p.pid))
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(h)
+
+	var counters processMemoryCounters
+	counters.cb = uint32(unsafe.Sizeof(counters))
+
+	ok, _, err := procGetProcessMemoryInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&counters)), uintptr(counters.cb))
+	if ok == 0 {
+		return 0, err
+	}
+
+	return uint64(counters.WorkingSetSize), nil
+}
+
+// PCpu returns this process's CPU usage as a percentage of a single core,
+// averaged over its entire lifetime: GetProcessTimes' kernel+user time
+// divided by wall-clock age (now - creation time).
+func (p *WindowsProcess) PCpu() (float64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(p.pid))
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(h)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+
+	var nowFT windows.Filetime
+	windows.GetSystemTimeAsFileTime(&nowFT)
+
+	cpuTime := filetimeToSeconds(kernel) + filetimeToSeconds(user)
+	age := filetimeToSeconds(nowFT) - filetimeToSeconds(creation)
+	if age <= 0 {
+		return 0, nil
+	}
+
+	return cpuTime / age * 100, nil
+}
+
+// CPUSeconds returns this process's cumulative CPU time (kernel+user, via
+// GetProcessTimes), in seconds. Unlike PCpu, which divides this by the
+// process's whole-lifetime age, CPUSeconds lets a caller take two
+// readings some interval apart and diff them into a windowed rate (see
+// checkProcs' --cpu-sample-interval).
+func (p *WindowsProcess) CPUSeconds() (float64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(p.pid))
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(h)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+
+	return filetimeToSeconds(kernel) + filetimeToSeconds(user), nil
+}
+
+// filetimeToSeconds converts a FILETIME (100ns ticks since 1601-01-01)
+// into seconds, for use as a common unit when differencing two of them.
+func filetimeToSeconds(ft windows.Filetime) float64 {
+	ticks := uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+	return float64(ticks) / 1e7
+}
+
+// Cmdline is not supported on Windows: unlike /proc/<pid>/cmdline, there
+// is no documented API that returns another process's argv without
+// reading its PEB via ReadProcessMemory, which additionally requires
+// matching 32/64-bit-ness between caller and target.
+func (p *WindowsProcess) Cmdline() ([]string, error) {
+	return nil, errUnsupported("Cmdline")
+}
+
+// UID is not supported on Windows: processes are owned by a SID, not a
+// POSIX-style numeric user ID.
+func (p *WindowsProcess) UID() (int, error) {
+	return 0, errUnsupported("UID")
+}
+
+// Children returns the processes whose parent is p. When recursive is
+// true, descendants of those children are folded in too.
+func (p *WindowsProcess) Children(recursive bool) []Process {
+	return childrenOf(p.pid, recursive)
+}
+
+// ChildrenProcInfo aggregates Rss and PCpu across p and p.Children(recursive).
+func (p *WindowsProcess) ChildrenProcInfo(recursive bool) ProcInfo {
+	return childrenProcInfoOf(p, recursive)
+}
+
+// findProcess looks up a single pid within a fresh toolhelp snapshot.
+func findProcess(pid int) (Process, error) {
+	all, err := processes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range all {
+		if p.Pid() == pid {
+			return p, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// processes walks a CreateToolhelp32Snapshot(TH32CS_SNAPPROCESS, 0)
+// snapshot via Process32First/Process32Next.
+func processes() ([]Process, error) {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snap)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	results := make([]Process, 0, 64)
+	if err := windows.Process32First(snap, &entry); err != nil {
+		return nil, err
+	}
+	for {
+		results = append(results, newWindowsProcess(&entry))
+
+		if err := windows.Process32Next(snap, &entry); err != nil {
+			if err == windows.ERROR_NO_MORE_FILES {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// newWindowsProcess converts a toolhelp PROCESSENTRY32 into a
+// WindowsProcess.
+func newWindowsProcess(entry *windows.ProcessEntry32) *WindowsProcess {
+	end := 0
+	for end < len(entry.ExeFile) && entry.ExeFile[end] != 0 {
+		end++
+	}
+
+	return &WindowsProcess{
+		pid:    int(entry.ProcessID),
+		ppid:   int(entry.ParentProcessID),
+		binary: windows.UTF16ToString(entry.ExeFile[:end]),
+	}
+}
@@ -0,0 +1,175 @@
+// Package procs provides a reusable API for listing and inspecting system
+// processes, including walking a process's descendant tree and aggregating
+// their resource usage. checkProcs' CLI is a thin wrapper around this
+// package.
+//
+// The process table itself is read differently on every platform, so the
+// enumeration and per-process field parsing live in build-tagged files
+// (process_linux.go, process_darwin.go, ...); this file holds the parts
+// that are the same everywhere.
+package procs
+
+import "fmt"
+
+// Process is the generic interface that is implemented on every platform
+// and provides common operations for processes.
+type Process interface {
+	// Pid is the process ID for this process.
+	Pid() int
+
+	// PPid is the parent process ID for this process.
+	PPid() int
+
+	// Executable name running this process. This is not a path to the
+	// executable.
+	Executable() string
+
+	// Path is the resolved path to the executable backing this process.
+	// It returns an error if the path cannot be resolved (e.g. the
+	// process has already exited, or the caller lacks permission).
+	Path() (string, error)
+
+	// Rss returns the resident set size of this process, in bytes.
+	Rss() (uint64, error)
+
+	// Cmdline returns the process's argv, as it was invoked.
+	Cmdline() ([]string, error)
+
+	// UID returns the numeric ID of the user owning this process.
+	UID() (int, error)
+
+	// PCpu returns the process's CPU usage as a percentage. On Linux and
+	// Windows it is computed from its cumulative CPU time since it
+	// started; on FreeBSD and Solaris it is the kernel's own recent %CPU
+	// figure; on Darwin it returns an error.
+	PCpu() (float64, error)
+
+	// Children returns the processes whose parent is this process. When
+	// recursive is true, descendants of those children are included too.
+	Children(recursive bool) []Process
+
+	// ChildrenProcInfo aggregates Rss and PCpu across this process and
+	// its Children(recursive).
+	ChildrenProcInfo(recursive bool) ProcInfo
+}
+
+// ProcInfo carries resource usage aggregated across one or more processes,
+// as produced by Process.ChildrenProcInfo.
+type ProcInfo struct {
+	// Pid is the process the aggregation started from.
+	Pid int
+
+	// Pids holds every process ID folded into this ProcInfo, including
+	// Pid itself.
+	Pids []int
+
+	// Rss is the summed resident set size, in bytes.
+	Rss uint64
+
+	// PCpu is the summed CPU usage percentage.
+	PCpu float64
+}
+
+// Add folds other's Rss, PCpu and Pids into pi.
+func (pi *ProcInfo) Add(other ProcInfo) {
+	pi.Pids = append(pi.Pids, other.Pids...)
+	pi.Rss += other.Rss
+	pi.PCpu += other.PCpu
+}
+
+// Processes returns all processes.
+//
+// This of course will be a point-in-time snapshot of when this method was
+// called. Some operating systems don't provide snapshot capability of the
+// process table, in which case the process table returned might contain
+// ephemeral entities that happened to be running when this was called.
+//
+// Example:
+// procs, _ := Processes()
+func Processes() ([]Process, error) {
+	return processes()
+}
+
+// FindProcess looks up a single process by pid.
+//
+// Process will be nil and error will be nil if a matching process is
+// not found.
+//
+// Example:
+// foo, _ := FindProcess(4256)
+// fmt.Println(foo.Executable(), foo.Pid())
+// for _, i := range procs {
+//   if i.Executable()
+func FindProcess(pid int) (Process, error) {
+	return findProcess(pid)
+}
+
+// childrenOf is the platform-agnostic implementation backing every
+// Process.Children method: it walks the full process table and picks out
+// whoever claims pid as their parent.
+func childrenOf(pid int, recursive bool) []Process {
+	all, err := Processes()
+	if err != nil {
+		return nil
+	}
+
+	return collectChildren(all, pid, recursive)
+}
+
+// collectChildren returns the members of all whose PPid is parent. When
+// recursive is true it also walks back in for each match, so grandchildren
+// (and beyond) are included.
+func collectChildren(all []Process, parent int, recursive bool) []Process {
+	var direct []Process
+	for _, proc := range all {
+		if proc.PPid() == parent {
+			direct = append(direct, proc)
+		}
+	}
+
+	if !recursive {
+		return direct
+	}
+
+	descendants := append([]Process{}, direct...)
+	for _, child := range direct {
+		descendants = append(descendants, collectChildren(all, child.Pid(), true)...)
+	}
+
+	return descendants
+}
+
+// childrenProcInfoOf is the platform-agnostic implementation backing every
+// Process.ChildrenProcInfo method: it aggregates Rss and PCpu across p and
+// p.Children(recursive).
+//
+// A process whose Rss or PCpu can't be read (e.g. it exited mid-walk) is
+// folded in with zero values rather than failing the whole aggregation.
+func childrenProcInfoOf(p Process, recursive bool) ProcInfo {
+	info := procInfoFor(p)
+	for _, child := range childrenOf(p.Pid(), recursive) {
+		info.Add(procInfoFor(child))
+	}
+
+	return info
+}
+
+// procInfoFor builds a single-process ProcInfo out of p, used as the
+// building block for childrenProcInfoOf's aggregation.
+func procInfoFor(p Process) ProcInfo {
+	rss, _ := p.Rss()
+	pcpu, _ := p.PCpu()
+
+	return ProcInfo{
+		Pid:  p.Pid(),
+		Pids: []int{p.Pid()},
+		Rss:  rss,
+		PCpu: pcpu,
+	}
+}
+
+// errUnsupported is returned by Process methods that a platform has no
+// reasonable way to implement.
+func errUnsupported(op string) error {
+	return fmt.Errorf("procs: %s is not supported on this platform", op)
+}